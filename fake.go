@@ -0,0 +1,288 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// primaryFields returns the fields of structure that make up its primary key.
+func primaryFields(structure Structure) []Field {
+	var pk []Field
+	for _, field := range structure.fields {
+		if field.tags["primary"] == "true" {
+			pk = append(pk, field)
+		}
+	}
+	return pk
+}
+
+// fakeKeyType returns the Go type used as the map key of the in-memory fake
+// repository. A single-column primary key keeps its own type; a composite
+// primary key is joined into a string key instead.
+func fakeKeyType(pk []Field) string {
+	if len(pk) == 1 {
+		return pk[0].Type
+	}
+	return "string"
+}
+
+// fakeKeyExpr returns the expression that computes the map key from a
+// variable named varName of type *<packageName>.<Name>.
+func fakeKeyExpr(pk []Field, varName string) string {
+	return fakeKeyExprFromAccessors(pk, func(field Field) string {
+		return varName + "." + field.Name
+	})
+}
+
+// fakeKeyExprFromParams returns the expression that computes the map key from
+// pk's own field names, used where the primary key fields are themselves the
+// function parameters (as in Select and Delete).
+func fakeKeyExprFromParams(pk []Field) string {
+	return fakeKeyExprFromAccessors(pk, func(field Field) string {
+		return field.Name
+	})
+}
+
+// fakeKeyExprFromAccessors builds the map key expression for pk, rendering
+// each field with accessor.
+func fakeKeyExprFromAccessors(pk []Field, accessor func(Field) string) string {
+	if len(pk) == 1 {
+		return accessor(pk[0])
+	}
+
+	keyExpr := "fmt.Sprintf(\""
+	for range pk {
+		keyExpr += "%v|"
+	}
+	keyExpr = keyExpr[:len(keyExpr)-1] + "\""
+	for _, field := range pk {
+		keyExpr += ", " + accessor(field)
+	}
+	keyExpr += ")"
+	return keyExpr
+}
+
+// fakeCloneFields returns statements that copy every slice/map field of
+// varName into fresh backing storage in place, so a stored fake element never
+// shares a top-level slice or map with a copy handed out to (or received from)
+// a caller. The copy is one level deep: pointer fields, and slices, maps or
+// pointers held inside a slice or map, are still shared.
+func fakeCloneFields(structure Structure, varName string) string {
+	out := ""
+	for _, field := range structure.fields {
+		switch {
+		case strings.HasPrefix(field.Type, "[]"):
+			out += fmt.Sprintf("\tif %s.%s != nil {\n", varName, field.Name)
+			out += fmt.Sprintf("\t\tcloned := make(%s, len(%s.%s))\n", field.Type, varName, field.Name)
+			out += fmt.Sprintf("\t\tcopy(cloned, %s.%s)\n", varName, field.Name)
+			out += fmt.Sprintf("\t\t%s.%s = cloned\n", varName, field.Name)
+			out += "\t}\n"
+		case strings.HasPrefix(field.Type, "map["):
+			out += fmt.Sprintf("\tif %s.%s != nil {\n", varName, field.Name)
+			out += fmt.Sprintf("\t\tcloned := make(%s, len(%s.%s))\n", field.Type, varName, field.Name)
+			out += fmt.Sprintf("\t\tfor k, v := range %s.%s {\n\t\t\tcloned[k] = v\n\t\t}\n", varName, field.Name)
+			out += fmt.Sprintf("\t\t%s.%s = cloned\n", varName, field.Name)
+			out += "\t}\n"
+		}
+	}
+	return out
+}
+
+// GenerateFakeFile writes the in-memory fake implementation of
+// <Name>Manager used by tests, mirroring structure's real repository.
+func GenerateFakeFile(structure *Structure) error {
+	pk := primaryFields(*structure)
+	if len(pk) == 0 {
+		return fmt.Errorf("GenerateFakeFile: %s has no primary key field", structure.name)
+	}
+	keyType := fakeKeyType(pk)
+
+	data := bytes.Buffer{}
+	data.WriteString(header)
+	data.WriteString("\n\n")
+
+	data.WriteString("import (\n")
+	data.WriteString("\t\"context\"\n")
+	data.WriteString("\t\"fmt\"\n")
+	if hasJSONFields(*structure) {
+		data.WriteString("\t\"reflect\"\n")
+	}
+	data.WriteString("\t\"sort\"\n")
+	data.WriteString("\t\"sync\"\n")
+	data.WriteString("\n")
+	data.WriteString(fmt.Sprintf("\t%q\n", structure.packageName))
+	data.WriteString(")\n\n")
+
+	data.WriteString(fmt.Sprintf("// Fake%sRepository is an in-memory %sManager used by tests.\n", structure.name, structure.name))
+	data.WriteString(fmt.Sprintf("type Fake%sRepository struct {\n", structure.name))
+	data.WriteString("\tmu   sync.RWMutex\n")
+	data.WriteString(fmt.Sprintf("\tdata map[%s]*%s.%s\n", keyType, structure.packageName, structure.name))
+	data.WriteString("}\n\n")
+
+	data.WriteString(fmt.Sprintf("// NewFake%sRepository creates an empty Fake%sRepository.\n", structure.name, structure.name))
+	data.WriteString(fmt.Sprintf("func NewFake%sRepository() *Fake%sRepository {\n", structure.name, structure.name))
+	data.WriteString(fmt.Sprintf("\treturn &Fake%sRepository{data: map[%s]*%s.%s{}}\n", structure.name, keyType, structure.packageName, structure.name))
+	data.WriteString("}\n\n")
+
+	data.WriteString(generateFakeCreate(*structure, pk))
+	data.WriteString(generateFakeSelect(*structure, pk))
+	data.WriteString(generateFakeUpdate(*structure, pk))
+	data.WriteString(generateFakeDelete(*structure, pk))
+	data.WriteString(generateFakeList(*structure, pk))
+
+	return os.WriteFile(fmt.Sprintf("repository/%s_repository_fake.go", structure.name), data.Bytes(), 0666)
+}
+
+// generateFakeCreate generates Fake<Name>Repository.<Name>Create.
+func generateFakeCreate(structure Structure, pk []Field) string {
+	name := structure.name + "Create"
+	keyExpr := fakeKeyExpr(pk, "data")
+
+	out := fmt.Sprintf("// %s add new %s to the fake repository\n", name, structure.name)
+	out += fmt.Sprintf("func (f *Fake%sRepository) %s(ctx context.Context, data *%s.%s) (err error) {\n", structure.name, name, structure.packageName, structure.name)
+	out += "\tf.mu.Lock()\n"
+	out += "\tdefer f.mu.Unlock()\n\n"
+	out += fmt.Sprintf("\tkey := %s\n", keyExpr)
+	out += "\tif _, ok := f.data[key]; ok {\n"
+	out += fmt.Sprintf("\t\treturn fmt.Errorf(\"%s error: duplicate key %%v\", key)\n", name)
+	out += "\t}\n\n"
+	out += "\tcopied := *data\n"
+	out += fakeCloneFields(structure, "copied")
+	out += "\tf.data[key] = &copied\n\n"
+	out += "\treturn nil\n"
+	out += "}\n\n"
+
+	return out
+}
+
+// generateFakeSelect generates Fake<Name>Repository.<Name>Select.
+func generateFakeSelect(structure Structure, pk []Field) string {
+	name := structure.name + "Select"
+
+	iParams := "ctx context.Context, "
+	for _, field := range pk {
+		iParams += fmt.Sprintf("%s %s, ", field.Name, field.Type)
+	}
+	iParams = iParams[:len(iParams)-2]
+
+	keyExpr := fakeKeyExprFromParams(pk)
+
+	out := fmt.Sprintf("// %s get %s from the fake repository by pk\n", name, structure.name)
+	out += fmt.Sprintf("func (f *Fake%sRepository) %s(%s) (element *%s.%s, err error) {\n", structure.name, name, iParams, structure.packageName, structure.name)
+	out += "\tf.mu.RLock()\n"
+	out += "\tdefer f.mu.RUnlock()\n\n"
+	out += fmt.Sprintf("\tkey := %s\n", keyExpr)
+	out += "\tfound, ok := f.data[key]\n"
+	out += "\tif !ok {\n"
+	out += fmt.Sprintf("\t\treturn nil, fmt.Errorf(\"%s error: not found\")\n", name)
+	out += "\t}\n\n"
+	out += "\tcopied := *found\n"
+	out += fakeCloneFields(structure, "copied")
+	out += "\treturn &copied, nil\n"
+	out += "}\n\n"
+
+	return out
+}
+
+// generateFakeUpdate generates Fake<Name>Repository.<Name>Update.
+func generateFakeUpdate(structure Structure, pk []Field) string {
+	name := structure.name + "Update"
+	keyExpr := fakeKeyExpr(pk, "data")
+
+	out := fmt.Sprintf("// %s replace %s in the fake repository by pk\n", name, structure.name)
+	out += fmt.Sprintf("func (f *Fake%sRepository) %s(ctx context.Context, data *%s.%s) (err error) {\n", structure.name, name, structure.packageName, structure.name)
+	out += "\tf.mu.Lock()\n"
+	out += "\tdefer f.mu.Unlock()\n\n"
+	out += fmt.Sprintf("\tkey := %s\n", keyExpr)
+	out += "\tif _, ok := f.data[key]; !ok {\n"
+	out += fmt.Sprintf("\t\treturn fmt.Errorf(\"%s error: not found\")\n", name)
+	out += "\t}\n\n"
+	out += "\tcopied := *data\n"
+	out += fakeCloneFields(structure, "copied")
+	out += "\tf.data[key] = &copied\n\n"
+	out += "\treturn nil\n"
+	out += "}\n\n"
+
+	return out
+}
+
+// generateFakeDelete generates Fake<Name>Repository.<Name>Delete.
+func generateFakeDelete(structure Structure, pk []Field) string {
+	name := structure.name + "Delete"
+
+	iParams := "ctx context.Context, "
+	for _, field := range pk {
+		iParams += fmt.Sprintf("%s %s, ", field.Name, field.Type)
+	}
+	iParams = iParams[:len(iParams)-2]
+
+	keyExpr := fakeKeyExprFromParams(pk)
+
+	out := fmt.Sprintf("// %s remove %s from the fake repository by pk\n", name, structure.name)
+	out += fmt.Sprintf("func (f *Fake%sRepository) %s(%s) (err error) {\n", structure.name, name, iParams)
+	out += "\tf.mu.Lock()\n"
+	out += "\tdefer f.mu.Unlock()\n\n"
+	out += fmt.Sprintf("\tkey := %s\n", keyExpr)
+	out += "\tdelete(f.data, key)\n\n"
+	out += "\treturn nil\n"
+	out += "}\n\n"
+
+	return out
+}
+
+// generateFakeList generates Fake<Name>Repository.<Name>List, applying
+// filter/limit/offset in memory over the map's values. matched is sorted by
+// primary key before paginating, since map iteration order is randomized and
+// would otherwise make List non-deterministic across calls.
+func generateFakeList(structure Structure, pk []Field) string {
+	name := structure.name + "List"
+
+	out := fmt.Sprintf("// %s returns %s rows matching filter, paginated, from the fake repository\n", name, structure.name)
+	out += fmt.Sprintf("func (f *Fake%sRepository) %s(ctx context.Context, filter *%sFilter, limit, offset int, orderBy string) (elements []*%s.%s, err error) {\n", structure.name, name, structure.name, structure.packageName, structure.name)
+	out += "\tf.mu.RLock()\n"
+	out += "\tdefer f.mu.RUnlock()\n\n"
+	out += "\tmatched := make([]*" + structure.packageName + "." + structure.name + ", 0, len(f.data))\n"
+	out += "\tfor _, element := range f.data {\n"
+	out += "\t\tcopied := *element\n"
+	for _, line := range strings.Split(strings.TrimRight(fakeCloneFields(structure, "copied"), "\n"), "\n") {
+		if line == "" {
+			continue
+		}
+		out += "\t" + line + "\n"
+	}
+	out += "\t\tif !" + structure.name + "MatchesFilter(&copied, filter) {\n"
+	out += "\t\t\tcontinue\n"
+	out += "\t\t}\n"
+	out += "\t\tmatched = append(matched, &copied)\n"
+	out += "\t}\n\n"
+	out += fmt.Sprintf("\tsort.Slice(matched, func(i, j int) bool {\n\t\treturn %s < %s\n\t})\n\n", fakeKeyExpr(pk, "matched[i]"), fakeKeyExpr(pk, "matched[j]"))
+	out += "\tif offset >= len(matched) {\n"
+	out += "\t\treturn nil, nil\n"
+	out += "\t}\n"
+	out += "\tmatched = matched[offset:]\n"
+	out += "\tif limit > 0 && limit < len(matched) {\n"
+	out += "\t\tmatched = matched[:limit]\n"
+	out += "\t}\n\n"
+	out += "\treturn matched, nil\n"
+	out += "}\n\n"
+
+	out += fmt.Sprintf("// %sMatchesFilter reports whether element satisfies every non-nil field of filter.\n", structure.name)
+	out += fmt.Sprintf("func %sMatchesFilter(element *%s.%s, filter *%sFilter) bool {\n", structure.name, structure.packageName, structure.name, structure.name)
+	out += "\tif filter == nil {\n\t\treturn true\n\t}\n"
+	for _, field := range structure.fields {
+		if field.tags["primary"] == "true" {
+			continue
+		}
+		if field.IsJSON {
+			out += fmt.Sprintf("\tif filter.%s != nil && !reflect.DeepEqual(element.%s, *filter.%s) {\n\t\treturn false\n\t}\n", field.Name, field.Name, field.Name)
+			continue
+		}
+		out += fmt.Sprintf("\tif filter.%s != nil && element.%s != *filter.%s {\n\t\treturn false\n\t}\n", field.Name, field.Name, field.Name)
+	}
+	out += "\treturn true\n"
+	out += "}\n"
+
+	return out
+}
@@ -0,0 +1,63 @@
+package main
+
+import "fmt"
+
+// jsonCreateValueExpr returns the expression used as the argument to
+// p.db.Exec/p.db.QueryRow for field, wrapping it in jsonColumn when it is
+// backed by a jsonb column so it marshals via driver.Valuer.
+func jsonCreateValueExpr(field Field, varName string) string {
+	if field.IsJSON {
+		return fmt.Sprintf("jsonColumn{v: &%s.%s}", varName, field.Name)
+	}
+	return varName + "." + field.Name
+}
+
+// jsonScanValueExpr returns the expression used as a Scan destination for
+// field, wrapping it in jsonColumn when it is backed by a jsonb column so it
+// unmarshals via sql.Scanner.
+func jsonScanValueExpr(field Field, varName string) string {
+	if field.IsJSON {
+		return fmt.Sprintf("&jsonColumn{v: &%s.%s}", varName, field.Name)
+	}
+	return "&" + varName + "." + field.Name
+}
+
+// jsonColumnTypeName is the helper type emitted into a generated file when it
+// has at least one jsonb-backed field.
+const jsonColumnTypeName = "jsonColumn"
+
+// generateJSONColumnHelper generates the jsonColumn type, used to marshal
+// slice/map fields to jsonb on write and unmarshal them back on read.
+func generateJSONColumnHelper() string {
+	return `// jsonColumn adapts a Go value to a jsonb column, marshaling it on write
+// (driver.Valuer) and unmarshaling into it on read (sql.Scanner).
+type jsonColumn struct {
+	v interface{}
+}
+
+// Value implements driver.Valuer.
+func (j jsonColumn) Value() (driver.Value, error) {
+	return json.Marshal(j.v)
+}
+
+// Scan implements sql.Scanner.
+func (j *jsonColumn) Scan(src interface{}) error {
+	b, ok := src.([]byte)
+	if !ok {
+		return fmt.Errorf("jsonColumn: unsupported scan type %T", src)
+	}
+	return json.Unmarshal(b, j.v)
+}
+`
+}
+
+// hasJSONFields reports whether structure has any field backed by a jsonb
+// column, which determines whether the jsonColumn helper needs to be emitted.
+func hasJSONFields(structure Structure) bool {
+	for _, field := range structure.fields {
+		if field.IsJSON {
+			return true
+		}
+	}
+	return false
+}
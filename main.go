@@ -9,7 +9,6 @@ import (
 	"go/token"
 	"os"
 	"path/filepath"
-	"strconv"
 	"strings"
 )
 
@@ -26,6 +25,9 @@ type Method struct {
 type Field struct {
 	Name string
 	Type string
+	// IsJSON marks fields (slices, maps) that must be marshaled to/from a
+	// jsonb column rather than scanned directly.
+	IsJSON bool
 	// map of tags tagName:tagValue
 	tags map[string]string
 }
@@ -42,18 +44,48 @@ type Structure struct {
 type Generator struct {
 	filePath string
 
-	header    string
-	imports   []string
-	structure Structure
-	methods   []*Method
+	header     string
+	imports    []string
+	extraTypes []string
+	structure  Structure
+	methods    []*Method
 
 	data bytes.Buffer
 }
 
 var targetFile string
 
+var (
+	dsn       string
+	driver    string
+	dbPackage string
+	naming    string
+)
+
+func init() {
+	flag.StringVar(&dsn, "dsn", "", "data source name of a live database to introspect instead of parsing a Go source file")
+	flag.StringVar(&driver, "driver", "postgres", "driver to use when -dsn is set (postgres or mysql)")
+	flag.StringVar(&dbPackage, "package", "model", "package name to use for structs generated from -dsn")
+	flag.StringVar(&naming, "naming", "snake_plural", "naming strategy used to infer table and column names (snake_plural)")
+	flag.StringVar(&templatesDir, "templates", "", "directory of .tmpl files overriding the embedded defaults")
+}
+
 func main() {
 	flag.Parse()
+
+	tmpl, err := loadTemplates(templatesDir)
+	if err != nil {
+		panic(err)
+	}
+	templates = tmpl
+
+	if dsn != "" {
+		if err := generateFromDB(); err != nil {
+			panic(err)
+		}
+		return
+	}
+
 	// We accept either one directory or a list of files. Which do we have?
 	args := flag.Args()
 	if len(args) == 0 {
@@ -74,11 +106,33 @@ func main() {
 		panic(err)
 	}
 
+	strategy := namingStrategy(naming)
+
+	siblings := map[string]*ast.TypeSpec{}
+	for _, v := range f.Decls {
+		genDecl, ok := v.(*ast.GenDecl)
+		if !ok || genDecl.Tok != token.TYPE {
+			continue
+		}
+		typeSpec := genDecl.Specs[0].(*ast.TypeSpec)
+		siblings[typeSpec.Name.Name] = typeSpec
+	}
+
+	embedded := embeddedTypeNames(siblings)
+
 	structures := make([]*Structure, 0, len(f.Decls))
 	for _, v := range f.Decls {
 		genDecl := v.(*ast.GenDecl)
 		if genDecl.Tok == token.TYPE {
-			structures = append(structures, getStructure(packageName, genDecl.Specs[0].(*ast.TypeSpec)))
+			typeSpec := genDecl.Specs[0].(*ast.TypeSpec)
+			if embedded[typeSpec.Name.Name] {
+				// typeSpec exists only to be embedded into another struct in
+				// this file (e.g. a shared base with no primary key of its
+				// own); getFields already promotes its columns onto whatever
+				// embeds it, so it isn't generated as a repository itself.
+				continue
+			}
+			structures = append(structures, getStructure(packageName, typeSpec, strategy, siblings))
 		}
 
 	}
@@ -92,6 +146,41 @@ func main() {
 
 }
 
+// generateFromDB connects to the database described by -dsn/-driver, lists its
+// tables and generates a repository for each one, mirroring the DB-first
+// workflow of tools like gorm/gen.
+func generateFromDB() error {
+	db, err := connectDB(driver, dsn)
+	if err != nil {
+		return fmt.Errorf("generateFromDB: %w", err)
+	}
+	defer db.Close()
+
+	tables, err := listTables(db, driver)
+	if err != nil {
+		return fmt.Errorf("generateFromDB: %w", err)
+	}
+
+	strategy := namingStrategy(naming)
+
+	for _, table := range tables {
+		structure, err := getStructureFromDB(db, driver, dbPackage, table, strategy)
+		if err != nil {
+			return fmt.Errorf("generateFromDB: %w", err)
+		}
+
+		if err := generateModelFile(structure); err != nil {
+			return fmt.Errorf("generateFromDB: %w", err)
+		}
+
+		if err := Generate(structure); err != nil {
+			return fmt.Errorf("generateFromDB: %w", err)
+		}
+	}
+
+	return nil
+}
+
 func (g *Generator) generateDirPath() (string, error) {
 	dir := filepath.Dir(g.filePath)
 	if dir == "model" {
@@ -116,72 +205,164 @@ func (g *Generator) generateDirPath() (string, error) {
 	return path, nil
 }
 
-// getStructure returns structure from file
-func getStructure(packageName string, typeSpec *ast.TypeSpec) *Structure {
-	list := typeSpec.Type.(*ast.StructType).Fields.List
-	structFields := make([]Field, len(list))
-	for i, v := range list {
-		var tagsArr []string
-		if v.Tag != nil {
-			a := v.Tag.Value
-			a = strings.TrimSuffix(a, " ")
-			a = strings.ReplaceAll(a, "`", "")
-			a = strings.ReplaceAll(a, "\"", "")
-			a = strings.ReplaceAll(a, ":", " ")
-			tagsArr = strings.Split(a, " ")
-		}
-		tagMap := map[string]string{}
-		for j := 0; j < len(tagsArr); j += 2 {
-			tagMap[tagsArr[j]] = tagsArr[j+1]
-		}
-
-		indent, ok := v.Type.(*ast.Ident)
-		fieldType := ""
-		if ok {
-			fieldType = indent.Name
-		} else {
-			indent := v.Type.(*ast.SelectorExpr)
-			fieldType = fmt.Sprintf("%s.%s", indent.X.(*ast.Ident).Name, indent.Sel.Name)
-		}
-		structFields[i] = Field{
-			Name: v.Names[0].Name,
-			Type: fieldType,
-			tags: tagMap,
+// embeddedTypeNames returns the names of the siblings that are embedded
+// (anonymously, with no field name of their own) into another sibling
+// struct, e.g. a shared Base struct embedded into several entities.
+func embeddedTypeNames(siblings map[string]*ast.TypeSpec) map[string]bool {
+	embedded := map[string]bool{}
+	for _, typeSpec := range siblings {
+		for _, field := range typeSpec.Type.(*ast.StructType).Fields.List {
+			if len(field.Names) != 0 {
+				continue
+			}
+			name := strings.TrimPrefix(exprToTypeString(field.Type), "*")
+			if _, ok := siblings[name]; ok {
+				embedded[name] = true
+			}
 		}
 	}
+	return embedded
+}
+
+// getStructure returns structure from file. siblings holds every struct type
+// declared in the same file, keyed by name, so that embedded fields referring
+// to one of them can be flattened into the parent's field list.
+func getStructure(packageName string, typeSpec *ast.TypeSpec, strategy NamingStrategy, siblings map[string]*ast.TypeSpec) *Structure {
+	list := typeSpec.Type.(*ast.StructType).Fields.List
 
 	return &Structure{
 		packageName: packageName,
-		tableName:   "testTable",
+		tableName:   strategy.TableName(typeSpec.Name.Name),
 		name:        typeSpec.Name.Name,
-		fields:      structFields,
+		fields:      getFields(list, strategy, siblings),
 	}
 }
 
-// generateExec generates the exec request
-func generateExec(methodName, sqlRequest, insertingValueString string) (execString string) {
-	execString += "\tctg, err := p.db.Exec(ctx, " + sqlRequest + ", " + insertingValueString + ")\n"
-	execString += "\tif err != nil {\n"
-	execString += fmt.Sprintf("\t\treturn fmt.Errorf(\"%s error: %v \", err)\n", methodName, "%w")
-	execString += "\t}\n"
+// getFields extracts the Fields described by list, recursing into embedded
+// struct fields (those with no explicit name) when the embedded type is
+// declared in the same file, so its columns are promoted onto the parent.
+func getFields(list []*ast.Field, strategy NamingStrategy, siblings map[string]*ast.TypeSpec) []Field {
+	structFields := make([]Field, 0, len(list))
+	for _, v := range list {
+		tagMap := parseTags(v.Tag)
+		fieldType := exprToTypeString(v.Type)
+
+		if len(v.Names) == 0 {
+			embeddedName := strings.TrimPrefix(fieldType, "*")
+			if embedded, ok := siblings[embeddedName]; ok {
+				embeddedList := embedded.Type.(*ast.StructType).Fields.List
+				structFields = append(structFields, getFields(embeddedList, strategy, siblings)...)
+				continue
+			}
+
+			if tagMap["column"] == "" {
+				tagMap["column"] = strategy.ColumnName(embeddedName)
+			}
+			structFields = append(structFields, Field{
+				Name:   embeddedName,
+				Type:   fieldType,
+				IsJSON: isJSONType(fieldType),
+				tags:   tagMap,
+			})
+			continue
+		}
 
-	execString += "\tif ctg.RowsAffected() == 0 {\n"
-	execString += fmt.Sprintf("\t\treturn fmt.Errorf(\"%s error: no rows affected\")\n", methodName)
-	execString += "\t}\n\n"
+		fieldName := v.Names[0].Name
+		if tagMap["column"] == "" {
+			tagMap["column"] = strategy.ColumnName(fieldName)
+		}
 
-	execString += "\treturn nil"
+		structFields = append(structFields, Field{
+			Name:   fieldName,
+			Type:   fieldType,
+			IsJSON: isJSONType(fieldType),
+			tags:   tagMap,
+		})
+	}
+
+	return structFields
+}
+
+// parseTags turns a struct tag literal into a tagName -> tagValue map.
+func parseTags(tag *ast.BasicLit) map[string]string {
+	var tagsArr []string
+	if tag != nil {
+		a := tag.Value
+		a = strings.TrimSuffix(a, " ")
+		a = strings.ReplaceAll(a, "`", "")
+		a = strings.ReplaceAll(a, "\"", "")
+		a = strings.ReplaceAll(a, ":", " ")
+		tagsArr = strings.Split(a, " ")
+	}
+
+	tagMap := map[string]string{}
+	for j := 0; j < len(tagsArr); j += 2 {
+		tagMap[tagsArr[j]] = tagsArr[j+1]
+	}
+	return tagMap
+}
+
+// isJSONType reports whether a field of this Go type should be stored as a
+// jsonb column (slices and maps, save for raw []byte which maps to bytea).
+func isJSONType(fieldType string) bool {
+	if fieldType == "[]byte" {
+		return false
+	}
+	return strings.HasPrefix(fieldType, "[]") || strings.HasPrefix(fieldType, "map[")
+}
+
+// exprToTypeString renders a field's type expression as the Go type string
+// used in generated code, recursing through pointers, slices, maps and
+// nested selectors instead of assuming a plain identifier or selector.
+func exprToTypeString(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		return t.Name
+	case *ast.SelectorExpr:
+		return exprToTypeString(t.X) + "." + t.Sel.Name
+	case *ast.StarExpr:
+		return "*" + exprToTypeString(t.X)
+	case *ast.ArrayType:
+		if t.Len != nil {
+			return fmt.Sprintf("[%s]%s", exprToTypeString(t.Len), exprToTypeString(t.Elt))
+		}
+		return "[]" + exprToTypeString(t.Elt)
+	case *ast.MapType:
+		return fmt.Sprintf("map[%s]%s", exprToTypeString(t.Key), exprToTypeString(t.Value))
+	case *ast.InterfaceType:
+		return "interface{}"
+	case *ast.StructType:
+		return "struct{}"
+	default:
+		return fmt.Sprintf("%T", expr)
+	}
+}
+
+// generateExec generates the exec request
+func generateExec(methodName, sqlRequest, insertingValueString string) (execString string) {
+	execString, err := renderTemplate("exec", execTemplateData{
+		MethodName: methodName,
+		SQLRequest: sqlRequest,
+		Values:     insertingValueString,
+	})
+	if err != nil {
+		panic(err)
+	}
 
 	return execString
 }
 
 // generateQueryRow generates the queryRow request
 func generateQueryRow(methodName, sqlRequest, whereValue, scanString string) (queryRowString string) {
-	queryRowString += "\terr = p.db.QueryRow(ctx, " + sqlRequest + ", " + whereValue + ").Scan(" + scanString + ")\n"
-	queryRowString += "\tif err != nil {\n"
-	queryRowString += fmt.Sprintf("\t\treturn nil, fmt.Errorf(\"%s error: %v \", err)\n", methodName, "%w")
-	queryRowString += "\t}\n\n"
-
-	queryRowString += "\treturn element, nil"
+	queryRowString, err := renderTemplate("queryrow", queryRowTemplateData{
+		MethodName: methodName,
+		SQLRequest: sqlRequest,
+		WhereValue: whereValue,
+		ScanString: scanString,
+	})
+	if err != nil {
+		panic(err)
+	}
 
 	return queryRowString
 }
@@ -191,23 +372,18 @@ func generateCreate(structure Structure) *Method {
 	method := Method{}
 
 	method.name = structure.name + "Create"
-	method.comment = "// " + method.name + " add new " + structure.name + " to database"
+	method.comment = renderComment(method.name, "add new "+structure.name+" to database")
 	method.iParams = "ctx context.Context, data *" + structure.packageName + "." + structure.name
 	method.oParams = "err error"
 
-	paramsString := ""
-	valuesString := ""
-	insertingValueString := ""
-	index := 1
-	for _, field := range structure.fields {
-		valuesString += fmt.Sprintf("$%s, ", strconv.Itoa(index))
-		paramsString += fmt.Sprintf("%s, ", field.tags["column"])
-		insertingValueString += fmt.Sprintf("data.%s, ", field.Name)
-		index++
+	fields := make([]fieldData, len(structure.fields))
+	for i, field := range structure.fields {
+		fields[i] = fieldData{Column: field.tags["column"], Expr: jsonCreateValueExpr(field, "data")}
 	}
-	valuesString = strings.TrimRight(valuesString, ", ")
-	paramsString = strings.TrimRight(paramsString, ", ")
-	insertingValueString = strings.TrimRight(insertingValueString, ", ")
+
+	paramsString := renderFieldList("columnList", fields)
+	valuesString := renderFieldList("placeholderList", fields)
+	insertingValueString := renderFieldList("valueList", fields)
 
 	sqlRequest := fmt.Sprintf("\"INSERT INTO %s (%s) VALUES (%s)\"", structure.tableName, paramsString, valuesString)
 	method.body += generateExec(method.name, sqlRequest, insertingValueString)
@@ -220,30 +396,25 @@ func generateSelect(structure Structure) *Method {
 	method := Method{}
 
 	method.name = structure.name + "Select"
-	method.comment = "// " + method.name + " get " + structure.name + " from database by pk"
-	method.iParams = "ctx context.Context, "
+	method.comment = renderComment(method.name, "get "+structure.name+" from database by pk")
 	method.oParams = "element *" + structure.packageName + "." + structure.name + ", err error"
 
-	whereString := ""
-	whereValue := ""
-	paramsString := ""
-	scanString := ""
-	index := 1
-	for _, field := range structure.fields {
-		if field.tags["primary"] == "true" {
-			method.iParams += fmt.Sprintf("%s %s, ", field.Name, field.Type)
-			whereValue += fmt.Sprintf("%s, ", field.Name)
-			whereString += fmt.Sprintf("%s = $%v AND ", field.tags["column"], index)
-			index++
-		}
-		paramsString += field.tags["column"] + ", "
-		scanString += "element." + field.Name + ", "
+	pk := primaryFields(structure)
+	pkFields := make([]fieldData, len(pk))
+	for i, field := range pk {
+		pkFields[i] = fieldData{Column: field.tags["column"], Name: field.Name, Type: field.Type, Placeholder: i + 1}
+	}
+
+	allFields := make([]fieldData, len(structure.fields))
+	for i, field := range structure.fields {
+		allFields[i] = fieldData{Column: field.tags["column"], Expr: jsonScanValueExpr(field, "element")}
 	}
-	method.iParams = strings.TrimRight(method.iParams, ", ")
-	whereString = strings.TrimRight(whereString, "AND ")
-	whereValue = strings.TrimRight(whereValue, ", ")
-	paramsString = strings.TrimSuffix(paramsString, ", ")
-	scanString = strings.TrimSuffix(scanString, ", ")
+
+	method.iParams = "ctx context.Context, " + renderFieldList("paramList", pkFields)
+	whereString := renderFieldList("whereList", pkFields)
+	whereValue := renderFieldList("nameList", pkFields)
+	paramsString := renderFieldList("columnList", allFields)
+	scanString := renderFieldList("valueList", allFields)
 
 	sqlRequest := fmt.Sprintf("\"SELECT (%s) FROM %s WHERE (%s)\"", paramsString, structure.tableName, whereString)
 	method.body += generateQueryRow(method.name, sqlRequest, whereValue, scanString)
@@ -256,24 +427,18 @@ func generateDelete(structure Structure) *Method {
 	method := Method{}
 
 	method.name = structure.name + "Delete"
-	method.comment = "// " + method.name + " delete " + structure.name + " from database by pk"
-	method.iParams = "ctx context.Context, "
+	method.comment = renderComment(method.name, "delete "+structure.name+" from database by pk")
 	method.oParams = "err error"
 
-	whereString := ""
-	whereValue := ""
-	index := 1
-	for _, field := range structure.fields {
-		if field.tags["primary"] == "true" {
-			method.iParams += fmt.Sprintf("%s %s, ", field.Name, field.Type)
-			whereValue += fmt.Sprintf("%s, ", field.Name)
-			whereString += fmt.Sprintf("%s = $%v AND ", field.tags["column"], index)
-			index++
-		}
+	pk := primaryFields(structure)
+	pkFields := make([]fieldData, len(pk))
+	for i, field := range pk {
+		pkFields[i] = fieldData{Column: field.tags["column"], Name: field.Name, Type: field.Type, Placeholder: i + 1}
 	}
-	method.iParams = strings.TrimRight(method.iParams, ", ")
-	whereString = strings.TrimRight(whereString, "AND ")
-	whereValue = strings.TrimRight(whereValue, ", ")
+
+	method.iParams = "ctx context.Context, " + renderFieldList("paramList", pkFields)
+	whereString := renderFieldList("whereList", pkFields)
+	whereValue := renderFieldList("nameList", pkFields)
 
 	sqlRequest := fmt.Sprintf("\"DELETE FROM %s WHERE (%s)\"", structure.tableName, whereString)
 	method.body += generateExec(method.name, sqlRequest, whereValue)
@@ -286,26 +451,24 @@ func generateUpdate(structure Structure) *Method {
 	method := Method{}
 
 	method.name = structure.name + "Update"
-	method.comment = "// " + method.name + " update " + structure.name + " in database by pk"
+	method.comment = renderComment(method.name, "update "+structure.name+" in database by pk")
 	method.iParams = "ctx context.Context, data *" + structure.packageName + "." + structure.name
 	method.oParams = "err error"
 
-	whereString := ""
-	paramsString := ""
-	valueString := ""
-	index := 1
-	for _, field := range structure.fields {
+	var whereFields, setFields, valueFields []fieldData
+	for i, field := range structure.fields {
+		fd := fieldData{Column: field.tags["column"], Placeholder: i + 1}
 		if field.tags["primary"] == "true" {
-			whereString += fmt.Sprintf("%s = $%v AND ", field.tags["column"], index)
+			whereFields = append(whereFields, fd)
 		} else {
-			paramsString += fmt.Sprintf("%s = $%v, ", field.tags["column"], index)
+			setFields = append(setFields, fd)
 		}
-		index++
-		valueString += "data." + field.Name + ", "
+		valueFields = append(valueFields, fieldData{Expr: jsonCreateValueExpr(field, "data")})
 	}
-	whereString = strings.TrimRight(whereString, "AND ")
-	paramsString = strings.TrimRight(paramsString, ", ")
-	valueString = strings.TrimRight(valueString, ", ")
+
+	whereString := renderFieldList("whereList", whereFields)
+	paramsString := renderFieldList("setList", setFields)
+	valueString := renderFieldList("valueList", valueFields)
 
 	sqlRequest := fmt.Sprintf("\"UPDATE %s SET (%s) WHERE (%s)\"", structure.tableName, paramsString, whereString)
 	method.body += generateExec(method.name, sqlRequest, valueString)
@@ -313,7 +476,100 @@ func generateUpdate(structure Structure) *Method {
 	return &method
 }
 
+// generateFilterStruct generates the <Name>Filter struct used by <Name>List to
+// optionally filter by equality on every non-primary column.
+func generateFilterStruct(structure Structure) string {
+	filterString := fmt.Sprintf("// %sFilter holds optional equality filters for %sList\n", structure.name, structure.name)
+	filterString += fmt.Sprintf("type %sFilter struct {\n", structure.name)
+	for _, field := range structure.fields {
+		if field.tags["primary"] == "true" {
+			continue
+		}
+		filterString += fmt.Sprintf("\t%s *%s\n", field.Name, field.Type)
+	}
+	filterString += "}\n"
+
+	return filterString
+}
+
+// generateColumnAllowlist generates the package-level var listing the columns
+// that orderBy is allowed to reference, to prevent SQL injection through it.
+func generateColumnAllowlist(structure Structure) string {
+	allowlist := fmt.Sprintf("// %sOrderableColumns are the columns %sList accepts in orderBy\n", structure.name, structure.name)
+	allowlist += fmt.Sprintf("var %sOrderableColumns = map[string]bool{\n", structure.name)
+	for _, field := range structure.fields {
+		allowlist += fmt.Sprintf("\t%q: true,\n", field.tags["column"])
+	}
+	allowlist += "}\n"
+
+	return allowlist
+}
+
+// generateList generates the List method, which filters, orders and paginates
+// over the table.
+func generateList(structure Structure) *Method {
+	method := Method{}
+
+	method.name = structure.name + "List"
+	method.comment = "// " + method.name + " returns " + structure.name + " rows matching filter, ordered and paginated"
+	method.iParams = fmt.Sprintf("ctx context.Context, filter *%sFilter, limit, offset int, orderBy string", structure.name)
+	method.oParams = fmt.Sprintf("elements []*%s.%s, err error", structure.packageName, structure.name)
+
+	paramsString := ""
+	scanString := ""
+	for _, field := range structure.fields {
+		paramsString += field.tags["column"] + ", "
+		scanString += jsonScanValueExpr(field, "element") + ", "
+	}
+	paramsString = strings.TrimSuffix(paramsString, ", ")
+	scanString = strings.TrimSuffix(scanString, ", ")
+
+	body := ""
+	body += fmt.Sprintf("\tsqlRequest := \"SELECT (%s) FROM %s\"\n", paramsString, structure.tableName)
+	body += "\targs := []interface{}{}\n"
+	body += "\tconditions := []string{}\n"
+
+	for _, field := range structure.fields {
+		if field.tags["primary"] == "true" {
+			continue
+		}
+		body += fmt.Sprintf("\tif filter != nil && filter.%s != nil {\n", field.Name)
+		body += "\t\targs = append(args, *filter." + field.Name + ")\n"
+		body += fmt.Sprintf("\t\tconditions = append(conditions, fmt.Sprintf(\"%s = $%%d\", len(args)))\n", field.tags["column"])
+		body += "\t}\n"
+	}
+
+	body += "\tif len(conditions) > 0 {\n"
+	body += "\t\tsqlRequest += \" WHERE \" + strings.Join(conditions, \" AND \")\n"
+	body += "\t}\n"
+	body += fmt.Sprintf("\tif orderBy != \"\" {\n\t\tif !%sOrderableColumns[orderBy] {\n\t\t\treturn nil, fmt.Errorf(\"%s error: %%s is not an orderable column\", orderBy)\n\t\t}\n\t\tsqlRequest += \" ORDER BY \" + orderBy\n\t}\n", structure.name, method.name)
+	body += "\targs = append(args, limit, offset)\n"
+	body += "\tsqlRequest += fmt.Sprintf(\" LIMIT $%d OFFSET $%d\", len(args)-1, len(args))\n\n"
+
+	body += "\trows, err := p.db.Query(ctx, sqlRequest, args...)\n"
+	body += "\tif err != nil {\n"
+	body += fmt.Sprintf("\t\treturn nil, fmt.Errorf(\"%s error: %%w\", err)\n", method.name)
+	body += "\t}\n"
+	body += "\tdefer rows.Close()\n\n"
+
+	body += fmt.Sprintf("\tfor rows.Next() {\n\t\telement := &%s.%s{}\n", structure.packageName, structure.name)
+	body += "\t\tif err := rows.Scan(" + scanString + "); err != nil {\n"
+	body += fmt.Sprintf("\t\t\treturn nil, fmt.Errorf(\"%s error: %%w\", err)\n", method.name)
+	body += "\t\t}\n"
+	body += "\t\telements = append(elements, element)\n"
+	body += "\t}\n\n"
+	body += "\treturn elements, rows.Err()"
+
+	method.body = body
+
+	return &method
+}
+
 func Generate(structure *Structure) error {
+	if len(primaryFields(*structure)) == 0 {
+		return fmt.Errorf("Generate: %s has no primary key field", structure.name)
+	}
+
 	generator := Generator{
 		filePath:  fmt.Sprintf("repository/%s_repository.go", structure.name),
 		structure: *structure,
@@ -323,6 +579,13 @@ func Generate(structure *Structure) error {
 	generator.AddHeader(header)
 	generator.AddImport("context")
 	generator.AddImport("fmt")
+	generator.AddImport(structure.packageName)
+
+	if hasJSONFields(*structure) {
+		generator.AddImport("database/sql/driver")
+		generator.AddImport("encoding/json")
+		generator.AddExtraType(generateJSONColumnHelper())
+	}
 
 	createMethod := generateCreate(*structure)
 	generator.AddMethod(createMethod)
@@ -336,7 +599,28 @@ func Generate(structure *Structure) error {
 	deleteMethod := generateDelete(*structure)
 	generator.AddMethod(deleteMethod)
 
-	return generator.GenerateFile()
+	generator.AddImport("strings")
+	generator.AddExtraType(generateFilterStruct(*structure))
+	generator.AddExtraType(generateColumnAllowlist(*structure))
+	listMethod := generateList(*structure)
+	generator.AddMethod(listMethod)
+
+	generator.AddImport("errors")
+	generator.AddImport("github.com/jackc/pgx/v5")
+
+	baseMethods := []*Method{createMethod, selectMethod, updateMethod, deleteMethod, listMethod}
+	generator.AddExtraType(generateTxManager(*structure, baseMethods))
+	for _, m := range baseMethods {
+		generator.AddExtraType(renderMethod(generateTxWrapper(*structure, m)))
+	}
+	generator.AddExtraType(renderMethod(generateCreateBatch(*structure)))
+	generator.AddExtraType(generateWithTx(*structure))
+
+	if err := generator.GenerateFile(); err != nil {
+		return err
+	}
+
+	return GenerateFakeFile(structure)
 }
 
 const header = `// code generated automatically
@@ -361,46 +645,35 @@ func (g *Generator) AddMethod(method *Method) {
 	g.methods = append(g.methods, method)
 }
 
+// AddExtraType adds a standalone type or var declaration, written after the
+// imports and before the Manager interface.
+func (g *Generator) AddExtraType(code string) {
+	g.extraTypes = append(g.extraTypes, code)
+}
+
 // GenerateFile generates the file
 func (g *Generator) GenerateFile() error {
-	// add header
-	g.data.WriteString(g.header)
-	g.data.WriteString("\n")
-	g.data.WriteString("\n")
-
-	// add imports
-	g.data.WriteString("import (\n")
-	for _, importName := range g.imports {
-		g.data.WriteString("\t\"" + importName + "\"\n")
-	}
-	g.data.WriteString(")\n")
-	g.data.WriteString("\n")
-
-	// add interface
-	g.data.WriteString("// " + g.structure.name + "Manager interface to interact with database\n")
-	g.data.WriteString(fmt.Sprintf("type %sManager interface {\n", g.structure.name))
-	for _, method := range g.methods {
-		g.data.WriteString(fmt.Sprintf("\t%s(%s) (%s)\n", method.name, method.iParams, method.oParams))
-	}
-	g.data.WriteString("}\n")
-	g.data.WriteString("\n")
-
-	/*
-		// add struct
-		g.data.WriteString(fmt.Sprintf("type %s struct {\n", g.structure.name))
-		for _, field := range g.structure.fields {
-			g.data.WriteString(fmt.Sprintf("\t%s %s\n", field.Name, field.Type))
-		}
-		g.data.WriteString("}\n")
-		g.data.WriteString("\n")
-	*/
-	// add methods
-	for _, method := range g.methods {
-		g.data.WriteString(fmt.Sprintf("%s\n", method.comment))
-		g.data.WriteString(fmt.Sprintf("func (p *PostgresRepository) %s(%s) (%s){\n", method.name, method.iParams, method.oParams))
-		g.data.WriteString(fmt.Sprintf("%s\n", method.body))
-		g.data.WriteString("}\n\n")
+	methods := make([]templateMethod, len(g.methods))
+	for i, method := range g.methods {
+		methods[i] = toTemplateMethod(method)
+	}
+
+	rendered, err := renderTemplate("repository", repositoryTemplateData{
+		Imports:    g.imports,
+		ExtraTypes: g.extraTypes,
+		Structure:  struct{ Name string }{Name: g.structure.name},
+		Methods:    methods,
+	})
+	if err != nil {
+		return fmt.Errorf("GenerateFile: %w", err)
 	}
 
+	formatted, err := formatSource([]byte(rendered))
+	if err != nil {
+		return fmt.Errorf("GenerateFile: %w", err)
+	}
+
+	g.data.Write(formatted)
+
 	return os.WriteFile(g.filePath, g.data.Bytes(), 0666)
 }
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"strings"
+	"unicode"
+)
+
+// NamingStrategy derives table and column names from Go identifiers, and
+// vice versa.
+type NamingStrategy interface {
+	// TableName returns the table name for a struct named structName.
+	TableName(structName string) string
+	// ColumnName returns the column name for a field named fieldName.
+	ColumnName(fieldName string) string
+	// StructName returns the Go struct name for a table named tableName,
+	// reversing TableName's rules.
+	StructName(tableName string) string
+}
+
+// namingStrategies holds the strategies selectable via the -naming flag.
+var namingStrategies = map[string]NamingStrategy{
+	"snake_plural": SnakePluralNamingStrategy{},
+}
+
+// namingStrategy resolves the -naming flag to a NamingStrategy, falling back
+// to SnakePluralNamingStrategy when name is unknown or empty.
+func namingStrategy(name string) NamingStrategy {
+	if strategy, ok := namingStrategies[name]; ok {
+		return strategy
+	}
+	return SnakePluralNamingStrategy{}
+}
+
+// SnakePluralNamingStrategy is the default NamingStrategy. It converts
+// CamelCase struct names to snake_case and pluralizes them for table names
+// (ImportantPerson -> important_people), and converts field names to
+// snake_case for column names.
+type SnakePluralNamingStrategy struct{}
+
+// TableName implements NamingStrategy.
+func (SnakePluralNamingStrategy) TableName(structName string) string {
+	return pluralize(toSnakeCase(structName))
+}
+
+// ColumnName implements NamingStrategy.
+func (SnakePluralNamingStrategy) ColumnName(fieldName string) string {
+	return toSnakeCase(fieldName)
+}
+
+// StructName implements NamingStrategy.
+func (SnakePluralNamingStrategy) StructName(tableName string) string {
+	return toFieldName(singularize(tableName))
+}
+
+// toSnakeCase converts a CamelCase or PascalCase identifier to snake_case.
+func toSnakeCase(s string) string {
+	var b strings.Builder
+	runes := []rune(s)
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				prevLower := unicode.IsLower(runes[i-1])
+				nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+				if prevLower || (nextLower && unicode.IsUpper(runes[i-1])) {
+					b.WriteByte('_')
+				}
+			}
+			b.WriteRune(unicode.ToLower(r))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// uncountables are words whose plural form is identical to their singular form.
+var uncountables = map[string]bool{
+	"sheep":     true,
+	"fish":      true,
+	"series":    true,
+	"equipment": true,
+}
+
+// irregulars maps singular nouns to their irregular plural form.
+var irregulars = map[string]string{
+	"person": "people",
+	"child":  "children",
+	"man":    "men",
+	"woman":  "women",
+	"goose":  "geese",
+	"mouse":  "mice",
+	"tooth":  "teeth",
+	"foot":   "feet",
+}
+
+// pluralize pluralizes the last "word" of a snake_case name (the part after
+// the last underscore), leaving any preceding words untouched.
+func pluralize(s string) string {
+	prefix := ""
+	word := s
+	if i := strings.LastIndexByte(s, '_'); i >= 0 {
+		prefix, word = s[:i+1], s[i+1:]
+	}
+
+	if uncountables[word] {
+		return prefix + word
+	}
+	if plural, ok := irregulars[word]; ok {
+		return prefix + plural
+	}
+
+	switch {
+	case strings.HasSuffix(word, "y") && len(word) > 1 && !isVowel(word[len(word)-2]):
+		return prefix + word[:len(word)-1] + "ies"
+	case strings.HasSuffix(word, "s"), strings.HasSuffix(word, "x"), strings.HasSuffix(word, "z"),
+		strings.HasSuffix(word, "ch"), strings.HasSuffix(word, "sh"):
+		return prefix + word + "es"
+	case strings.HasSuffix(word, "fe"):
+		return prefix + word[:len(word)-2] + "ves"
+	case strings.HasSuffix(word, "f"):
+		return prefix + word[:len(word)-1] + "ves"
+	default:
+		return prefix + word + "s"
+	}
+}
+
+// irregularsReversed maps irregular plural nouns back to their singular
+// form, the inverse of irregulars.
+var irregularsReversed = map[string]string{
+	"people":   "person",
+	"children": "child",
+	"men":      "man",
+	"women":    "woman",
+	"geese":    "goose",
+	"mice":     "mouse",
+	"teeth":    "tooth",
+	"feet":     "foot",
+}
+
+// singularize singularizes the last "word" of a snake_case name (the part
+// after the last underscore), leaving any preceding words untouched. It
+// reverses pluralize's rules, but not exactly: a -ves plural always becomes
+// -fe (so "leaves" becomes "leafe"), and a -ses plural always loses its "es"
+// (so "houses" becomes "hous").
+func singularize(s string) string {
+	prefix := ""
+	word := s
+	if i := strings.LastIndexByte(s, '_'); i >= 0 {
+		prefix, word = s[:i+1], s[i+1:]
+	}
+
+	if uncountables[word] {
+		return prefix + word
+	}
+	if singular, ok := irregularsReversed[word]; ok {
+		return prefix + singular
+	}
+
+	switch {
+	case strings.HasSuffix(word, "ies") && len(word) > 3:
+		return prefix + word[:len(word)-3] + "y"
+	case strings.HasSuffix(word, "ves") && len(word) > 3:
+		return prefix + word[:len(word)-3] + "fe"
+	case strings.HasSuffix(word, "ses"), strings.HasSuffix(word, "xes"), strings.HasSuffix(word, "zes"),
+		strings.HasSuffix(word, "ches"), strings.HasSuffix(word, "shes"):
+		return prefix + word[:len(word)-2]
+	case strings.HasSuffix(word, "s") && len(word) > 1:
+		return prefix + word[:len(word)-1]
+	default:
+		return prefix + word
+	}
+}
+
+// isVowel reports whether b is an English vowel.
+func isVowel(b byte) bool {
+	switch b {
+	case 'a', 'e', 'i', 'o', 'u':
+		return true
+	default:
+		return false
+	}
+}
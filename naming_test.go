@@ -0,0 +1,71 @@
+package main
+
+import "testing"
+
+func TestToSnakeCase(t *testing.T) {
+	cases := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"single word", "Person", "person"},
+		{"two words", "ImportantPerson", "important_person"},
+		{"three words", "ImportantPersonName", "important_person_name"},
+		{"acronym run", "ID", "id"},
+		{"acronym then word", "UserID", "user_id"},
+		{"word then acronym", "HTTPServer", "http_server"},
+		{"acronym in the middle", "ParseJSONBody", "parse_json_body"},
+		{"already lowercase", "name", "name"},
+		{"empty", "", ""},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := toSnakeCase(c.in); got != c.want {
+				t.Errorf("toSnakeCase(%q) = %q, want %q", c.in, got, c.want)
+			}
+		})
+	}
+}
+
+func TestPluralize(t *testing.T) {
+	cases := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"regular", "cat", "cats"},
+		{"sibilant s", "bus", "buses"},
+		{"sibilant x", "box", "boxes"},
+		{"sibilant ch", "watch", "watches"},
+		{"sibilant sh", "dish", "dishes"},
+		{"consonant y", "city", "cities"},
+		{"vowel y", "day", "days"},
+		{"fe suffix", "knife", "knives"},
+		{"f suffix", "leaf", "leaves"},
+		{"irregular", "person", "people"},
+		{"irregular child", "child", "children"},
+		{"uncountable", "sheep", "sheep"},
+		{"uncountable equipment", "equipment", "equipment"},
+		{"prefixed word", "important_person", "important_people"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := pluralize(c.in); got != c.want {
+				t.Errorf("pluralize(%q) = %q, want %q", c.in, got, c.want)
+			}
+		})
+	}
+}
+
+func TestSnakePluralNamingStrategy(t *testing.T) {
+	strategy := SnakePluralNamingStrategy{}
+
+	if got, want := strategy.TableName("ImportantPerson"), "important_people"; got != want {
+		t.Errorf("TableName(%q) = %q, want %q", "ImportantPerson", got, want)
+	}
+	if got, want := strategy.ColumnName("CreatedAt"), "created_at"; got != want {
+		t.Errorf("ColumnName(%q) = %q, want %q", "CreatedAt", got, want)
+	}
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"go/format"
+	"path/filepath"
+	"text/template"
+)
+
+//go:embed templates/*.tmpl
+var embeddedTemplates embed.FS
+
+// templatesDir, when set via -templates, points at a directory of .tmpl files
+// that replace the embedded defaults of the same name.
+var templatesDir string
+
+// templates is the parsed set of templates used to render generated files.
+// It is populated by loadTemplates in main, after flags are parsed.
+var templates *template.Template
+
+// templateFuncs are made available to every template; inc lets a template
+// turn a 0-based range index into a 1-based SQL placeholder number.
+var templateFuncs = template.FuncMap{
+	"inc": func(i int) int { return i + 1 },
+}
+
+// loadTemplates parses the embedded default templates and then, if dir is
+// non-empty, parses every .tmpl file in dir on top of them, so that any
+// template a file there defines replaces the embedded one of the same name,
+// letting users override generation output without touching the generator
+// itself.
+func loadTemplates(dir string) (*template.Template, error) {
+	tmpl, err := template.New("").Funcs(templateFuncs).ParseFS(embeddedTemplates, "templates/*.tmpl")
+	if err != nil {
+		return nil, fmt.Errorf("loadTemplates: error while parsing embedded templates - %s", err)
+	}
+
+	if dir == "" {
+		return tmpl, nil
+	}
+
+	overrides, err := filepath.Glob(filepath.Join(dir, "*.tmpl"))
+	if err != nil {
+		return nil, fmt.Errorf("loadTemplates: error while globbing %s - %s", dir, err)
+	}
+
+	for _, override := range overrides {
+		tmpl, err = tmpl.ParseFiles(override)
+		if err != nil {
+			return nil, fmt.Errorf("loadTemplates: error while parsing override %s - %s", override, err)
+		}
+	}
+
+	return tmpl, nil
+}
+
+// execTemplateData is the data passed to the "exec" template.
+type execTemplateData struct {
+	MethodName string
+	SQLRequest string
+	Values     string
+}
+
+// queryRowTemplateData is the data passed to the "queryrow" template.
+type queryRowTemplateData struct {
+	MethodName string
+	SQLRequest string
+	WhereValue string
+	ScanString string
+}
+
+// renderTemplate executes the named template with data and returns the result.
+func renderTemplate(name string, data interface{}) (string, error) {
+	buf := bytes.Buffer{}
+	if err := templates.ExecuteTemplate(&buf, name, data); err != nil {
+		return "", fmt.Errorf("renderTemplate: error while executing template %q - %s", name, err)
+	}
+	return buf.String(), nil
+}
+
+// formatSource runs src through go/format.Source so generated files are
+// always gofmt-clean, and fails fast if generation produced invalid Go.
+func formatSource(src []byte) ([]byte, error) {
+	formatted, err := format.Source(src)
+	if err != nil {
+		return nil, fmt.Errorf("formatSource: error while formatting generated source - %s", err)
+	}
+	return formatted, nil
+}
+
+// fieldData exposes a Field's SQL-facing pieces to the fieldlist templates.
+// Placeholder is the $N number used for a field participating in a WHERE or
+// SET clause, precomputed in Go since it must follow the original struct
+// field order rather than the range index of whichever subset is rendered.
+type fieldData struct {
+	Column      string
+	Name        string
+	Type        string
+	Expr        string
+	Placeholder int
+}
+
+// renderFieldList renders the named fieldlist template (columnList,
+// placeholderList, valueList, paramList, whereList, setList) over fields.
+func renderFieldList(name string, fields []fieldData) string {
+	rendered, err := renderTemplate(name, fields)
+	if err != nil {
+		panic(err)
+	}
+	return rendered
+}
+
+// renderComment renders a method's doc comment via the methodComment template.
+func renderComment(name, text string) string {
+	rendered, err := renderTemplate("methodComment", struct{ Name, Text string }{Name: name, Text: text})
+	if err != nil {
+		panic(err)
+	}
+	return rendered
+}
+
+// repositoryTemplateData is the data passed to the "repository" template.
+type repositoryTemplateData struct {
+	Imports    []string
+	ExtraTypes []string
+	Structure  struct{ Name string }
+	Methods    []templateMethod
+}
+
+// templateMethod exposes Method's fields to text/template, which cannot
+// reach unexported struct fields.
+type templateMethod struct {
+	Name    string
+	Comment string
+	IParams string
+	OParams string
+	Body    string
+}
+
+// toTemplateMethod converts a Method to its template-facing representation.
+func toTemplateMethod(method *Method) templateMethod {
+	return templateMethod{
+		Name:    method.name,
+		Comment: method.comment,
+		IParams: method.iParams,
+		OParams: method.oParams,
+		Body:    method.body,
+	}
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// renderMethod renders m as a standalone function on *PostgresRepository.
+func renderMethod(m *Method) string {
+	return fmt.Sprintf("%s\nfunc (p *PostgresRepository) %s(%s) (%s) {\n%s\n}\n", m.comment, m.name, m.iParams, m.oParams, m.body)
+}
+
+// extractParamNames returns the parameter names declared in iParams (a
+// func-parameter list as built by this package's method generators), in
+// order, skipping the leading "ctx context.Context" parameter.
+func extractParamNames(iParams string) []string {
+	segments := strings.Split(iParams, ", ")
+	var names []string
+	for _, segment := range segments {
+		parts := strings.Fields(segment)
+		if len(parts) == 0 {
+			continue
+		}
+		if parts[0] == "ctx" {
+			continue
+		}
+		names = append(names, parts[0])
+	}
+	return names
+}
+
+// insertTxParam inserts a "tx pgx.Tx" parameter right after the leading ctx
+// parameter of iParams. It panics if iParams doesn't start with the expected
+// "ctx context.Context" prefix, rather than silently leaving tx out of the
+// signature while the generated body still references it.
+func insertTxParam(iParams string) string {
+	if iParams == "ctx context.Context" {
+		return iParams + ", tx pgx.Tx"
+	}
+	if strings.HasPrefix(iParams, "ctx context.Context, ") {
+		return strings.Replace(iParams, "ctx context.Context, ", "ctx context.Context, tx pgx.Tx, ", 1)
+	}
+	panic(fmt.Sprintf("insertTxParam: iParams %q does not start with ctx context.Context", iParams))
+}
+
+// generateTxManager generates the <Name>ManagerTx interface and its
+// <Name>TxManager implementation, which run methods's bodies against a
+// pgx.Tx instead of p.db.
+func generateTxManager(structure Structure, methods []*Method) string {
+	txManagerName := structure.name + "TxManager"
+
+	out := fmt.Sprintf("// %sManagerTx is the transactional counterpart of %sManager\n", structure.name, structure.name)
+	out += fmt.Sprintf("type %sManagerTx interface {\n", structure.name)
+	for _, m := range methods {
+		out += fmt.Sprintf("\t%s(%s) (%s)\n", m.name, m.iParams, m.oParams)
+	}
+	out += "}\n\n"
+
+	out += fmt.Sprintf("// %s implements %sManagerTx against an open pgx.Tx.\n", txManagerName, structure.name)
+	out += fmt.Sprintf("type %s struct {\n\ttx pgx.Tx\n}\n\n", txManagerName)
+
+	for _, m := range methods {
+		body := strings.ReplaceAll(m.body, "p.db.", "m.tx.")
+		out += fmt.Sprintf("%s\n", m.comment)
+		out += fmt.Sprintf("func (m *%s) %s(%s) (%s) {\n%s\n}\n\n", txManagerName, m.name, m.iParams, m.oParams, body)
+	}
+
+	return out
+}
+
+// generateTxWrapper generates the <Name>XTx(ctx, tx, ...) method on
+// PostgresRepository that runs m's logic against the given transaction.
+func generateTxWrapper(structure Structure, m *Method) *Method {
+	wrapped := &Method{}
+	wrapped.name = m.name + "Tx"
+	wrapped.comment = fmt.Sprintf("// %s runs %s against an already open transaction", wrapped.name, m.name)
+	wrapped.iParams = insertTxParam(m.iParams)
+	wrapped.oParams = m.oParams
+
+	args := append([]string{"ctx"}, extractParamNames(m.iParams)...)
+	wrapped.body = fmt.Sprintf("\treturn (&%sTxManager{tx: tx}).%s(%s)", structure.name, m.name, strings.Join(args, ", "))
+
+	return wrapped
+}
+
+// generateCreateBatch generates <Name>CreateBatch, which inserts every
+// element of data in a single round-trip via pgx.Batch.
+func generateCreateBatch(structure Structure) *Method {
+	method := &Method{}
+
+	method.name = structure.name + "CreateBatch"
+	method.comment = "// " + method.name + " inserts every " + structure.name + " in data in a single round-trip"
+	method.iParams = fmt.Sprintf("ctx context.Context, data []*%s.%s", structure.packageName, structure.name)
+	method.oParams = "err error"
+
+	paramsString := ""
+	valuesString := ""
+	insertingValueString := ""
+	index := 1
+	for _, field := range structure.fields {
+		valuesString += fmt.Sprintf("$%s, ", strconv.Itoa(index))
+		paramsString += fmt.Sprintf("%s, ", field.tags["column"])
+		insertingValueString += jsonCreateValueExpr(field, "item") + ", "
+		index++
+	}
+	valuesString = strings.TrimRight(valuesString, ", ")
+	paramsString = strings.TrimRight(paramsString, ", ")
+	insertingValueString = strings.TrimRight(insertingValueString, ", ")
+
+	sqlRequest := fmt.Sprintf("\"INSERT INTO %s (%s) VALUES (%s)\"", structure.tableName, paramsString, valuesString)
+
+	body := ""
+	body += "\tbatch := &pgx.Batch{}\n"
+	body += "\tfor _, item := range data {\n"
+	body += fmt.Sprintf("\t\tbatch.Queue(%s, %s)\n", sqlRequest, insertingValueString)
+	body += "\t}\n\n"
+	body += "\tresults := p.db.SendBatch(ctx, batch)\n"
+	body += "\tdefer results.Close()\n\n"
+	body += "\tvar errs []error\n"
+	body += "\tfor range data {\n"
+	body += "\t\tif _, err := results.Exec(); err != nil {\n"
+	body += fmt.Sprintf("\t\t\terrs = append(errs, fmt.Errorf(\"%s error: %%w\", err))\n", method.name)
+	body += "\t\t}\n"
+	body += "\t}\n"
+	body += "\tif len(errs) > 0 {\n"
+	body += "\t\treturn errors.Join(errs...)\n"
+	body += "\t}\n\n"
+	body += "\treturn nil"
+
+	method.body = body
+
+	return method
+}
+
+// generateWithTx generates <Name>WithTx, which opens a transaction, runs fn
+// against a <Name>ManagerTx bound to it, and commits on success or rolls back
+// on error.
+func generateWithTx(structure Structure) string {
+	name := structure.name + "WithTx"
+
+	out := fmt.Sprintf("// %s opens a transaction, runs fn against a %sManagerTx bound to it,\n", name, structure.name)
+	out += "// and commits on success or rolls back if fn (or the commit) fails.\n"
+	out += fmt.Sprintf("func (p *PostgresRepository) %s(ctx context.Context, fn func(%sManagerTx) error) (err error) {\n", name, structure.name)
+	out += "\ttx, err := p.db.Begin(ctx)\n"
+	out += "\tif err != nil {\n"
+	out += fmt.Sprintf("\t\treturn fmt.Errorf(\"%s error: %%w\", err)\n", name)
+	out += "\t}\n"
+	out += "\tdefer tx.Rollback(ctx)\n\n"
+
+	out += fmt.Sprintf("\tif err := fn(&%sTxManager{tx: tx}); err != nil {\n", structure.name)
+	out += fmt.Sprintf("\t\treturn fmt.Errorf(\"%s error: %%w\", err)\n", name)
+	out += "\t}\n\n"
+
+	out += "\tif err := tx.Commit(ctx); err != nil {\n"
+	out += fmt.Sprintf("\t\treturn fmt.Errorf(\"%s error: %%w\", err)\n", name)
+	out += "\t}\n\n"
+
+	out += "\treturn nil\n"
+	out += "}\n"
+
+	return out
+}